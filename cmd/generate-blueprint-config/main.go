@@ -32,13 +32,46 @@ func generateBlueprintConfig() litestream.Config {
 			S3SecretAccessKey: "YOUR_SECRET_ACCESS_KEY", // Placeholder: Set via env or secrets management
 			S3ForcePathStyle:  false,                    // Set to true for MinIO or other S3-compatibles
 			// S3SkipVerify:   false, // Set to true if using self-signed certs (use with caution)
+			AgeRecipients: []string{"age1examplerecipientxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxxx"}, // Optional: encrypt payloads at rest; decrypt with the app's -age-key identity
+		},
+		{
+			Name:      "gcs_backup_example", // Unique name for the GCS replica
+			Type:      "gcs",
+			GCSBucket: "your-gcs-bucket-name", // Placeholder: Your GCS bucket name
+			GCSPath:   "backups/myapp",        // Optional: Path prefix in the bucket
+			// Auth is via Application Default Credentials: set
+			// GOOGLE_APPLICATION_CREDENTIALS=/path/to/service-account.json
+			// in the environment running this daemon.
+		},
+		{
+			Name:           "abs_backup_example", // Unique name for the Azure Blob Storage replica
+			Type:           "abs",
+			ABSAccountName: "your-storage-account", // Placeholder: Azure storage account name
+			ABSAccountKey:  "YOUR_ACCOUNT_KEY",      // Placeholder: Set via env or secrets management
+			ABSContainer:   "your-container-name",   // Placeholder: Azure Blob Storage container name
+			ABSPath:        "backups/myapp",         // Optional: Path prefix in the container
+		},
+		{
+			Name:        "sftp_backup_example", // Unique name for the SFTP replica
+			Type:        "sftp",
+			SFTPHost:    "sftp.example.com:22",      // Placeholder: SFTP host and port
+			SFTPUser:    "backup-user",              // Placeholder: SFTP username
+			SFTPKeyPath: "/path/to/id_ed25519",       // Placeholder: Path to SSH private key
+			SFTPPath:    "backups/myapp",             // Optional: Path prefix on the remote host
 		},
 		// Add more replica examples if needed
 	}
 
-	// Create the main config struct (DBPath is removed)
+	// Create the main config struct. Databases lists every SQLite file the
+	// daemon replicates; add one entry per auxiliary database (jobs, cache,
+	// analytics, ...) alongside the main one.
 	cfg := litestream.Config{
-		Replicas: replicas,
+		Databases: []litestream.DatabaseConfig{
+			{
+				Path:     "/path/to/your/app.db", // Placeholder: Database file to back up
+				Replicas: replicas,
+			},
+		},
 	}
 
 	return cfg
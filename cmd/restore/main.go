@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/superfly/ltx"
+
+	"github.com/caasmo/restinpieces-litestream"
+)
+
+func main() {
+	configPath := flag.String("config", "", "Path to the Litestream TOML configuration file (required)")
+	outputPath := flag.String("output", "", "Path to write the restored database file (required)")
+	dbPath := flag.String("db", "", "Path of the configured database to restore from, as it appears in the config file (default: consider every configured database)")
+	replicaName := flag.String("replica", "", "Name of the replica to restore from (default: newest across all configured replicas)")
+	txidStr := flag.String("txid", "", "Transaction ID to restore (default: newest as of -timestamp); must match exactly one replica, narrowed with -db and -replica if needed")
+	timestamp := flag.String("timestamp", "", "Restore the database as of this RFC 3339 timestamp (default: latest available)")
+	ifDBNotExists := flag.Bool("if-db-not-exists", false, "Exit successfully without restoring if a file already exists at -output")
+	ifReplicaExists := flag.Bool("if-replica-exists", false, "Exit successfully without restoring if no matching replica/generation is found")
+	ageKeyPath := flag.String("age-key", "", "Path to the age identity (private key) file, required if the replica is age-encrypted")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s -config <litestream.toml> -output <database-path> [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Restore a database from a Litestream replica using point-in-time recovery.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	if *configPath == "" || *outputPath == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: slog.LevelInfo,
+	}))
+
+	var opts litestream.RestoreOptions
+	opts.DatabasePath = *dbPath
+	opts.ReplicaName = *replicaName
+	if *txidStr != "" {
+		txid, err := ltx.ParseTXID(*txidStr)
+		if err != nil {
+			logger.Error("invalid -txid", "error", err)
+			os.Exit(1)
+		}
+		opts.TXID = txid
+	}
+	opts.IfDBNotExists = *ifDBNotExists
+	opts.IfReplicaExists = *ifReplicaExists
+	opts.AgeIdentityPath = *ageKeyPath
+
+	if *timestamp != "" {
+		t, err := time.Parse(time.RFC3339, *timestamp)
+		if err != nil {
+			logger.Error("invalid -timestamp, expected RFC 3339", "error", err)
+			os.Exit(1)
+		}
+		opts.Timestamp = t
+	}
+
+	data, err := os.ReadFile(*configPath)
+	if err != nil {
+		logger.Error("failed to read config file", "path", *configPath, "error", err)
+		os.Exit(1)
+	}
+
+	var cfg litestream.Config
+	if err := toml.Unmarshal(data, &cfg); err != nil {
+		logger.Error("failed to parse config file", "path", *configPath, "error", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := litestream.Restore(ctx, cfg, *outputPath, opts, logger); err != nil {
+		logger.Error("restore failed", "error", err)
+		os.Exit(1)
+	}
+
+	logger.Info("restore completed successfully", "path", *outputPath)
+}
@@ -0,0 +1,120 @@
+package litestream
+
+// Config is the top-level configuration for a Litestream instance. It is
+// typically decoded from TOML (see cmd/generate-blueprint-config) and passed
+// to NewLitestream.
+type Config struct {
+	// Databases lists every database to replicate, each with its own path
+	// and replica set. Real deployments commonly replicate several
+	// auxiliary SQLite files (jobs, cache, analytics) alongside the main
+	// database through a single daemon.
+	Databases []DatabaseConfig `toml:"databases"`
+
+	// NotificationURLs are shoutrrr service URLs (Slack, email, Discord,
+	// Telegram, generic webhooks, ...) notified on backup lifecycle events.
+	// When empty, lifecycle events are only logged.
+	NotificationURLs []string `toml:"notification_urls,omitempty"`
+
+	// NotificationTemplates overrides the default text/template body used
+	// for a given event (see EventType), keyed by event name. Unlisted
+	// events fall back to the built-in default template.
+	NotificationTemplates map[string]string `toml:"notification_templates,omitempty"`
+
+	// MaxConcurrentReplicaOps bounds how many replicas may start or stop at
+	// once across every configured database. Zero or unset defaults to 4.
+	MaxConcurrentReplicaOps int `toml:"max_concurrent_replica_ops,omitempty"`
+
+	// SnapshotInterval is how often litestream takes a full snapshot.
+	// Accepts any value parseable by time.ParseDuration. Empty uses
+	// litestream's own default. This applies to every configured database
+	// and replica: litestream.Store schedules snapshots process-wide, not
+	// per replica.
+	SnapshotInterval string `toml:"snapshot_interval,omitempty"`
+
+	// SnapshotRetention is how long snapshots are kept before being
+	// pruned. Accepts any value parseable by time.ParseDuration. Empty
+	// uses litestream's own default. Like SnapshotInterval, this is a
+	// process-wide setting.
+	SnapshotRetention string `toml:"snapshot_retention,omitempty"`
+
+	// SyncErrorThreshold is how many consecutive sync errors a replica must
+	// accumulate before EventSyncError fires, so a single transient failure
+	// doesn't page anyone. Zero or unset defaults to 3.
+	SyncErrorThreshold int `toml:"sync_error_threshold,omitempty"`
+}
+
+// DatabaseConfig describes a single SQLite database to replicate and the
+// destinations its WAL should be streamed to.
+type DatabaseConfig struct {
+	// Path is the database file to back up. Required.
+	Path string `toml:"path"`
+
+	// Replicas lists every destination this database should be streamed to.
+	Replicas []ReplicaConfig `toml:"replicas"`
+
+	// MonitorInterval is how often litestream polls this database for
+	// changes. Accepts any value parseable by time.ParseDuration. Empty
+	// uses litestream's own default.
+	MonitorInterval string `toml:"monitor_interval,omitempty"`
+
+	// CheckpointInterval is how often litestream checkpoints this
+	// database's WAL back into the main database file. Accepts any value
+	// parseable by time.ParseDuration. Empty uses litestream's own default.
+	CheckpointInterval string `toml:"checkpoint_interval,omitempty"`
+}
+
+// ReplicaConfig describes a single replication destination. Type selects
+// which fields below are relevant; see the switch in newRawReplicaClient.
+type ReplicaConfig struct {
+	// Name uniquely identifies the replica within a database for logging
+	// and lookup purposes. Required.
+	Name string `toml:"name"`
+
+	// Type selects the replica backend: "file", "s3", "gcs", "abs", or
+	// "sftp".
+	Type string `toml:"type"`
+
+	// FilePath is the destination directory. Required when Type is "file".
+	FilePath string `toml:"file_path,omitempty"`
+
+	// S3* configure an S3 (or S3-compatible) destination. Required when
+	// Type is "s3".
+	S3Bucket          string `toml:"s3_bucket,omitempty"`
+	S3Path            string `toml:"s3_path,omitempty"`
+	S3Region          string `toml:"s3_region,omitempty"`
+	S3Endpoint        string `toml:"s3_endpoint,omitempty"`
+	S3AccessKeyID     string `toml:"s3_access_key_id,omitempty"`
+	S3SecretAccessKey string `toml:"s3_secret_access_key,omitempty"`
+	S3ForcePathStyle  bool   `toml:"s3_force_path_style,omitempty"`
+
+	// GCS* configure a Google Cloud Storage destination. Required when Type
+	// is "gcs". Authentication is via Application Default Credentials only
+	// (no per-replica service account fields): set GOOGLE_APPLICATION_CREDENTIALS
+	// in the process environment to the service account JSON key file.
+	GCSBucket string `toml:"gcs_bucket,omitempty"`
+	GCSPath   string `toml:"gcs_path,omitempty"`
+
+	// ABS* configure an Azure Blob Storage destination. Required when Type
+	// is "abs".
+	ABSAccountName string `toml:"abs_account_name,omitempty"`
+	ABSAccountKey  string `toml:"abs_account_key,omitempty"`
+	ABSContainer   string `toml:"abs_container,omitempty"`
+	ABSPath        string `toml:"abs_path,omitempty"`
+
+	// SFTP* configure an SFTP destination. Required when Type is "sftp".
+	SFTPHost    string `toml:"sftp_host,omitempty"`
+	SFTPUser    string `toml:"sftp_user,omitempty"`
+	SFTPKeyPath string `toml:"sftp_key_path,omitempty"`
+	SFTPPath    string `toml:"sftp_path,omitempty"`
+
+	// AgeRecipients, when set, enables at-rest encryption: snapshot and WAL
+	// segment payloads are encrypted to these age public keys before
+	// upload, regardless of backend. Decryption on restore uses the age
+	// identity file passed to the app (the same one used for restinpieces
+	// config decryption, e.g. via -age-key).
+	AgeRecipients []string `toml:"age_recipients,omitempty"`
+
+	// SyncInterval is how often the replica syncs the WAL to its
+	// destination. Accepts any value parseable by time.ParseDuration.
+	SyncInterval string `toml:"sync_interval,omitempty"`
+}
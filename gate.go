@@ -0,0 +1,32 @@
+package litestream
+
+// gate bounds how many replica operations (start/stop) run concurrently, in
+// the style of a buffered-channel semaphore: Start blocks acquiring a slot,
+// Done releases it. A nil gate is unbounded, so every slot acquisition is a
+// no-op.
+type gate chan struct{}
+
+// newGate creates a gate allowing up to n concurrent operations. n <= 0
+// means unbounded.
+func newGate(n int) gate {
+	if n <= 0 {
+		return nil
+	}
+	return make(gate, n)
+}
+
+// Start blocks until a slot is available.
+func (g gate) Start() {
+	if g == nil {
+		return
+	}
+	g <- struct{}{}
+}
+
+// Done releases a slot acquired by Start.
+func (g gate) Done() {
+	if g == nil {
+		return
+	}
+	<-g
+}
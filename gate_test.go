@@ -0,0 +1,79 @@
+package litestream
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGate_BoundsConcurrency(t *testing.T) {
+	const (
+		limit   = 3
+		workers = 10
+	)
+
+	g := newGate(limit)
+
+	var (
+		current int32
+		maxSeen int32
+		wg      sync.WaitGroup
+	)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			g.Start()
+			defer g.Done()
+
+			n := atomic.AddInt32(&current, 1)
+			for {
+				old := atomic.LoadInt32(&maxSeen)
+				if n <= old || atomic.CompareAndSwapInt32(&maxSeen, old, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&current, -1)
+		}()
+	}
+
+	wg.Wait()
+
+	if maxSeen > limit {
+		t.Fatalf("gate allowed %d concurrent operations, want at most %d", maxSeen, limit)
+	}
+}
+
+func TestGate_NilIsUnbounded(t *testing.T) {
+	var g gate // newGate(0) returns the same nil value
+
+	done := make(chan struct{})
+	go func() {
+		g.Start()
+		g.Start()
+		g.Start()
+		g.Done()
+		g.Done()
+		g.Done()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("nil gate blocked Start/Done")
+	}
+}
+
+func TestNewGate_NonPositiveIsUnbounded(t *testing.T) {
+	if g := newGate(0); g != nil {
+		t.Fatalf("newGate(0) = %v, want nil", g)
+	}
+	if g := newGate(-1); g != nil {
+		t.Fatalf("newGate(-1) = %v, want nil", g)
+	}
+}
@@ -0,0 +1,97 @@
+package litestream
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/superfly/ltx"
+
+	"github.com/benbjohnson/litestream"
+)
+
+// instrumentedReplicaClient wraps a litestream.ReplicaClient, updating m and
+// firing lifecycle notifications for every LTX file it writes and every
+// retention prune it performs. Every other ReplicaClient method is
+// inherited unchanged via embedding, mirroring the ageReplicaClient
+// pattern.
+type instrumentedReplicaClient struct {
+	litestream.ReplicaClient
+
+	m        *metrics
+	notifier *notifier
+	db       string
+	name     string
+}
+
+// newInstrumentedReplicaClient wraps client so every successful write
+// updates the metrics registered for the replica named name on database db
+// and notifies n of snapshot completions, sync errors and retention prunes.
+func newInstrumentedReplicaClient(client litestream.ReplicaClient, m *metrics, n *notifier, db, name string) litestream.ReplicaClient {
+	return &instrumentedReplicaClient{ReplicaClient: client, m: m, notifier: n, db: db, name: name}
+}
+
+// countingReader wraps an io.Reader, tracking the total number of bytes
+// read through it so callers can learn a payload's size without buffering
+// it, and optionally updating a gauge live as bytes flow through.
+type countingReader struct {
+	io.Reader
+	n      int64
+	onRead func(n int64)
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if n > 0 {
+		r.n += int64(n)
+		if r.onRead != nil {
+			r.onRead(int64(n))
+		}
+	}
+	return n, err
+}
+
+// WriteLTXFile uploads a single LTX file, litestream's unit of replication
+// since the LTX rewrite: level litestream.SnapshotLevel is a full snapshot,
+// every other level is an incremental range of transactions. walBytesPending
+// only tracks the latter, mirroring what the gauge's name promises.
+func (c *instrumentedReplicaClient) WriteLTXFile(ctx context.Context, level int, minTXID, maxTXID ltx.TXID, r io.Reader) (*ltx.FileInfo, error) {
+	start := time.Now()
+
+	isSnapshot := level == litestream.SnapshotLevel
+
+	cr := &countingReader{Reader: r}
+	if !isSnapshot {
+		pending := c.m.walBytesPending.WithLabelValues(c.db, c.name)
+		cr.onRead = func(n int64) { pending.Add(float64(n)) }
+		defer pending.Set(0)
+	}
+
+	info, err := c.ReplicaClient.WriteLTXFile(ctx, level, minTXID, maxTXID, cr)
+	if err != nil {
+		c.m.recordSyncError(c.db, c.name)
+		c.notifier.notifySyncError(c.db, c.name, err)
+		return info, err
+	}
+
+	c.notifier.notifySyncSuccess(c.db, c.name)
+	c.m.recordWrite(c.db, c.name, cr.n)
+	if isSnapshot {
+		c.m.recordSnapshot(c.db, c.name)
+		c.notifier.Notify(NotifyEvent{Type: EventSnapshotCompleted, Database: c.db, ReplicaName: c.name, BytesReplicated: cr.n, Duration: time.Since(start)})
+	}
+	return info, nil
+}
+
+// DeleteLTXFiles removes files from the replica destination as part of
+// litestream's own retention enforcement, firing EventRetentionPrune on
+// success.
+func (c *instrumentedReplicaClient) DeleteLTXFiles(ctx context.Context, a []*ltx.FileInfo) error {
+	if err := c.ReplicaClient.DeleteLTXFiles(ctx, a); err != nil {
+		return err
+	}
+	if len(a) > 0 {
+		c.notifier.Notify(NotifyEvent{Type: EventRetentionPrune, Database: c.db, ReplicaName: c.name})
+	}
+	return nil
+}
@@ -2,26 +2,46 @@ package litestream
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"os"
-	"path/filepath"
+	"sync"
 	"time"
 
 	"github.com/benbjohnson/litestream"
-	"github.com/benbjohnson/litestream/file"
-	"github.com/benbjohnson/litestream/s3"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // ConfigScope defines the default scope used when storing/retrieving
 // Litestream configuration securely (e.g., in a database).
 const ConfigScope = "litestream"
 
+// defaultMaxConcurrentReplicaOps is used when Config.MaxConcurrentReplicaOps
+// is unset.
+const defaultMaxConcurrentReplicaOps = 4
 
+// defaultCompactionLevels is the compaction policy passed to
+// litestream.NewStore. It reuses litestream's own default level ladder
+// (L0 raw files compacting up through L1/L2/L3 on increasing intervals)
+// rather than inventing one, since the Store drives compaction scheduling
+// off these levels directly.
+var defaultCompactionLevels = litestream.DefaultCompactionLevels
 
-// Litestream handles continuous database backups for potentially multiple replicas.
+// Litestream handles continuous database backups for potentially multiple
+// databases, each with potentially multiple replicas.
 type Litestream struct {
-	store  *litestream.Store // The Store object is now the central orchestrator
+	config Config
+	store  *litestream.Store // owns every *litestream.DB built from cfg
+
+	// entries tracks, for every configured replica, the *litestream.DB/
+	// *litestream.Replica pair built for it along with the database path
+	// and replica name it came from. litestream.DB holds at most one
+	// Replica (see newReplicaDB), so a database with several configured
+	// replicas gets one *litestream.DB per replica; entries is how the
+	// rest of the package (metrics, notifications, start/stop) still
+	// addresses a replica by name the way Config does.
+	entries []replicaEntry
+
 	logger *slog.Logger
 
 	// ctx controls the lifecycle of the backup process
@@ -32,151 +52,209 @@ type Litestream struct {
 
 	// shutdownDone signals when backup has completely stopped
 	shutdownDone chan struct{}
+
+	// metrics holds the prometheus collectors sampled from the replica
+	// goroutine loop. Always set: defaults to prometheus.DefaultRegisterer
+	// when WithMetrics is not supplied.
+	metrics *metrics
+
+	// notifier dispatches lifecycle events to the configured notification
+	// targets. Always set: degrades to log-only when cfg.NotificationURLs
+	// is empty.
+	notifier *notifier
+
+	// replicaGate bounds how many replicas start or stop at once, so a
+	// single misconfigured endpoint can't serialize startup/shutdown of
+	// all the others.
+	replicaGate gate
+
+	// stopOnce guards the shutdown notification/close so a repeat Stop call
+	// (e.g. from both a signal handler and a deferred cleanup) is a no-op
+	// rather than sending/closing on an already-closed notifier.
+	stopOnce sync.Once
+}
+
+// replicaEntry ties a *litestream.DB/*litestream.Replica pair back to the
+// database path and replica name they were configured from.
+type replicaEntry struct {
+	dbPath  string
+	name    string
+	db      *litestream.DB
+	replica *litestream.Replica
+}
+
+// Option configures optional behavior on a Litestream instance at
+// construction time.
+type Option func(*litestreamOptions)
+
+// litestreamOptions accumulates the effect of Option values passed to
+// NewLitestream.
+type litestreamOptions struct {
+	metricsRegisterer prometheus.Registerer
+	ageIdentityPath   string
+}
+
+// WithAgeIdentityPath configures the age identity file used to decrypt
+// replica payloads that were encrypted via ReplicaConfig.AgeRecipients.
+// Pass the same identity file the app already uses for config decryption
+// (the -age-key flag). Only required if a replica reads back encrypted
+// payloads (e.g. litestream's own generation/retention bookkeeping);
+// writing never needs the identity, only the recipients.
+func WithAgeIdentityPath(path string) Option {
+	return func(o *litestreamOptions) {
+		o.ageIdentityPath = path
+	}
+}
+
+// WithMetrics registers the litestream prometheus collectors on reg instead
+// of the default global registry. Pair it with Litestream.Handler to serve
+// them, e.g. via srv.AddDaemon(ls) and mux.Handle("/metrics", ls.Handler()).
+func WithMetrics(reg prometheus.Registerer) Option {
+	return func(o *litestreamOptions) {
+		o.metricsRegisterer = reg
+	}
 }
 
-// NewLitestream creates a new Litestream instance configured according to cfg.
-// It sets up the database object and initializes all replicas defined in cfg.Replicas.
-// The dbPath specifies the database file to back up.
-func NewLitestream(dbPath string, cfg Config, logger *slog.Logger) (*Litestream, error) {
-	if dbPath == "" {
-		return nil, fmt.Errorf("litestream: dbPath cannot be empty")
+// NewLitestream creates a new Litestream instance configured according to
+// cfg. It builds one *litestream.DB per configured replica (litestream.DB
+// supports only a single Replica, so a database with several replicas
+// becomes several *litestream.DB instances sharing the same underlying
+// file), and has the returned instance's *litestream.Store own all of
+// them. Use opts to customize optional behavior such as the prometheus
+// registry (see WithMetrics).
+func NewLitestream(cfg Config, logger *slog.Logger, opts ...Option) (*Litestream, error) {
+	if len(cfg.Databases) == 0 {
+		return nil, fmt.Errorf("litestream: no databases configured")
 	}
-	if len(cfg.Replicas) == 0 {
-		return nil, fmt.Errorf("litestream: no replicas configured")
+
+	o := litestreamOptions{metricsRegisterer: prometheus.DefaultRegisterer}
+	for _, opt := range opts {
+		opt(&o)
 	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	db := litestream.NewDB(dbPath)        // Use dbPath argument
-	db.Logger = logger.With("db", dbPath) // Use dbPath argument
-	// Ensure the Replicas slice is initialized before appending
-	db.Replicas = make([]*litestream.Replica, 0, len(cfg.Replicas))
+	m := newMetrics(o.metricsRegisterer)
 
-	// --- DB-Level settings ---
-	if cfg.MonitorInterval != "" {
-		d, err := time.ParseDuration(cfg.MonitorInterval)
-		if err != nil {
-			cancel()
-			return nil, fmt.Errorf("litestream: invalid monitor_interval format: %w", err)
-		}
-		db.MonitorInterval = d
-	}
-	if cfg.CheckpointInterval != "" {
-		d, err := time.ParseDuration(cfg.CheckpointInterval)
-		if err != nil {
-			cancel()
-			return nil, fmt.Errorf("litestream: invalid checkpoint_interval format: %w", err)
-		}
-		db.CheckpointInterval = d
+	n, err := newNotifier(cfg, logger)
+	if err != nil {
+		cancel()
+		return nil, err
 	}
 
-	// --- Configure Each Replica ---
-	for _, rc := range cfg.Replicas {
-		if rc.Name == "" {
+	var entries []replicaEntry
+	for _, dc := range cfg.Databases {
+		if dc.Path == "" {
 			cancel()
-			return nil, fmt.Errorf("litestream: replica name is required but missing for type '%s'", rc.Type)
+			return nil, fmt.Errorf("litestream: database path cannot be empty")
 		}
-
-		l := logger.With("replica_name", rc.Name, "replica_type", rc.Type)
-		var replicaClient litestream.ReplicaClient
-
-		switch rc.Type {
-		case "file":
-			if rc.FilePath == "" {
-				cancel()
-				return nil, fmt.Errorf("litestream: FilePath is required for file replica '%s'", rc.Name)
-			}
-			if err := os.MkdirAll(rc.FilePath, 0750); err != nil && !os.IsExist(err) {
-				cancel()
-				return nil, fmt.Errorf("litestream: failed to create file replica directory '%s' for replica '%s': %w", rc.FilePath, rc.Name, err)
-			}
-			absFilePath, err := filepath.Abs(rc.FilePath)
-			if err != nil {
-				cancel()
-				return nil, fmt.Errorf("litestream: failed to get absolute path for file replica '%s' path '%s': %w", rc.Name, rc.FilePath, err)
-			}
-			replicaClient = file.NewReplicaClient(absFilePath)
-			l.Info("Configured file replica client", "path", absFilePath)
-
-		case "s3":
-			s3Client := s3.NewReplicaClient()
-			s3Client.Bucket = rc.S3Bucket
-			s3Client.Path = rc.S3Path
-			s3Client.Region = rc.S3Region
-			s3Client.Endpoint = rc.S3Endpoint
-			s3Client.AccessKeyID = rc.S3AccessKeyID
-			s3Client.SecretAccessKey = rc.S3SecretAccessKey
-			s3Client.ForcePathStyle = rc.S3ForcePathStyle
-			// s3Client.SkipVerify = rc.S3SkipVerify // Add if needed
-
-			replicaClient = s3Client
-			l.Info("Configured S3 replica client", "endpoint", rc.S3Endpoint, "bucket", rc.S3Bucket, "path", rc.S3Path, "region", rc.S3Region)
-
-		default:
+		if len(dc.Replicas) == 0 {
 			cancel()
-			return nil, fmt.Errorf("litestream: unsupported replica type '%s' for replica '%s'", rc.Type, rc.Name)
+			return nil, fmt.Errorf("litestream: no replicas configured for database '%s'", dc.Path)
 		}
 
-		// Create the replica object and link it to the DB
-		replica := litestream.NewReplica(db, rc.Name)
-		replica.Client = replicaClient
-
-		// --- Replica-Level Settings ---
-		if rc.SyncInterval != "" {
-			d, err := time.ParseDuration(rc.SyncInterval)
-			if err != nil {
-				cancel()
-				return nil, fmt.Errorf("litestream: invalid sync_interval format for replica '%s': %w", rc.Name, err)
-			}
-			replica.SyncInterval = d
-		}
-		if rc.SnapshotInterval != "" {
-			d, err := time.ParseDuration(rc.SnapshotInterval)
-			if err != nil {
-				cancel()
-				return nil, fmt.Errorf("litestream: invalid snapshot_interval format for replica '%s': %w", rc.Name, err)
-			}
-			replica.SnapshotInterval = d
-		}
-		if rc.Retention != "" {
-			d, err := time.ParseDuration(rc.Retention)
+		for _, rc := range dc.Replicas {
+			db, replica, err := newReplicaDB(dc, rc, logger, o.ageIdentityPath, m, n)
 			if err != nil {
 				cancel()
-				// Note: Litestream's own parsing is more robust here, handling "0" for forever.
-				// For simplicity here, we parse duration, assuming non-zero means retain for that long.
-				// An empty string "" could also mean forever. Check litestream code if exact behavior is needed.
-				return nil, fmt.Errorf("litestream: invalid retention format for replica '%s': %w", rc.Name, err)
+				return nil, err
 			}
-			replica.Retention = d
+			entries = append(entries, replicaEntry{dbPath: dc.Path, name: rc.Name, db: db, replica: replica})
 		}
+	}
 
-		// Handle Retention="0" or empty string for forever (default behavior)
-		if rc.Retention == "" || rc.Retention == "0" {
-			replica.Retention = 0 // Explicitly set to 0 duration for "keep forever"
-		}
+	dbs := make([]*litestream.DB, 0, len(entries))
+	for _, e := range entries {
+		dbs = append(dbs, e.db)
+	}
 
-		if rc.RetentionCheckInterval != "" {
-			d, err := time.ParseDuration(rc.RetentionCheckInterval)
-			if err != nil {
-				cancel()
-				return nil, fmt.Errorf("litestream: invalid retention_check_interval format for replica '%s': %w", rc.Name, err)
-			}
-			replica.RetentionCheckInterval = d
+	store := litestream.NewStore(dbs, defaultCompactionLevels)
+	if cfg.SnapshotInterval != "" {
+		d, err := time.ParseDuration(cfg.SnapshotInterval)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("litestream: invalid snapshot_interval format: %w", err)
+		}
+		store.SnapshotInterval = d
+	}
+	if cfg.SnapshotRetention != "" {
+		d, err := time.ParseDuration(cfg.SnapshotRetention)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("litestream: invalid snapshot_retention format: %w", err)
 		}
+		store.SnapshotRetention = d
+	}
 
-		db.Replicas = append(db.Replicas, replica)
+	maxConcurrentReplicaOps := cfg.MaxConcurrentReplicaOps
+	if maxConcurrentReplicaOps <= 0 {
+		maxConcurrentReplicaOps = defaultMaxConcurrentReplicaOps
 	}
 
 	return &Litestream{
 		config:       cfg,
 		logger:       logger,
-		db:           db, // DB now holds the configured replicas
+		store:        store,
+		entries:      entries,
 		ctx:          ctx,
 		cancel:       cancel,
 		shutdownDone: make(chan struct{}),
+		metrics:      m,
+		notifier:     n,
+		replicaGate:  newGate(maxConcurrentReplicaOps),
 	}, nil
 }
 
+// newReplicaDB builds the *litestream.DB and *litestream.Replica backing a
+// single configured replica. litestream.DB supports only one Replica, so
+// every configured replica gets its own *litestream.DB pointed at the same
+// dc.Path, each with a metadata directory suffixed by the replica name so
+// their shadow state never collides on disk.
+func newReplicaDB(dc DatabaseConfig, rc ReplicaConfig, logger *slog.Logger, ageIdentityPath string, m *metrics, n *notifier) (*litestream.DB, *litestream.Replica, error) {
+	if rc.Name == "" {
+		return nil, nil, fmt.Errorf("litestream: replica name is required but missing for type '%s' (database '%s')", rc.Type, dc.Path)
+	}
+
+	l := logger.With("db", dc.Path, "replica_name", rc.Name, "replica_type", rc.Type)
+
+	replicaClient, err := newReplicaClient(rc, l, ageIdentityPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	replicaClient = newInstrumentedReplicaClient(replicaClient, m, n, dc.Path, rc.Name)
+
+	db := litestream.NewDB(dc.Path)
+	db.Logger = l
+	db.SetMetaPath(db.MetaPath() + "-" + rc.Name)
+
+	if dc.MonitorInterval != "" {
+		d, err := time.ParseDuration(dc.MonitorInterval)
+		if err != nil {
+			return nil, nil, fmt.Errorf("litestream: invalid monitor_interval format for database '%s': %w", dc.Path, err)
+		}
+		db.MonitorInterval = d
+	}
+	if dc.CheckpointInterval != "" {
+		d, err := time.ParseDuration(dc.CheckpointInterval)
+		if err != nil {
+			return nil, nil, fmt.Errorf("litestream: invalid checkpoint_interval format for database '%s': %w", dc.Path, err)
+		}
+		db.CheckpointInterval = d
+	}
+
+	replica := litestream.NewReplicaWithClient(db, replicaClient)
+	if rc.SyncInterval != "" {
+		d, err := time.ParseDuration(rc.SyncInterval)
+		if err != nil {
+			return nil, nil, fmt.Errorf("litestream: invalid sync_interval format for replica '%s': %w", rc.Name, err)
+		}
+		replica.SyncInterval = d
+	}
+	db.Replica = replica
+
+	return db, replica, nil
+}
+
 // Name returns the name of the service for logging and identification.
 func (l *Litestream) Name() string {
 	return "LitestreamBackup"
@@ -188,45 +266,32 @@ func (l *Litestream) Name() string {
 // process continues in the background. Any errors during individual replica
 // startup within the goroutine will be logged but won't stop the process.
 func (l *Litestream) Start() error {
-	l.logger.Info("💾 litestream: opening database for replication")
-	// Open database - this is the primary blocking operation before the goroutine.
-	if err := l.db.Open(); err != nil {
-		l.logger.Error("💾 litestream: failed to open database", "error", err)
-		return fmt.Errorf("litestream: failed to open database: %w", err)
+	l.logger.Info("💾 litestream: opening databases for replication")
+	// Open every database - this is the primary blocking operation before the goroutine.
+	if err := l.store.Open(l.ctx); err != nil {
+		l.logger.Error("💾 litestream: failed to open databases", "error", err)
+		return fmt.Errorf("litestream: failed to open databases: %w", err)
 	}
-	l.logger.Info("💾 litestream: database opened successfully")
+	l.logger.Info("💾 litestream: databases opened successfully")
 
 	// Channel to synchronize startup: reports error or nil for success
 	startupComplete := make(chan error, 1)
 
 	go func() {
-		var startupErr error // Track the first error encountered
-
 		defer close(l.shutdownDone)
 		defer func() {
-			l.logger.Info("💾 litestream: closing database")
-			if err := l.db.Close(); err != nil {
-				l.logger.Error("💾 litestream: error closing database during shutdown", "error", err)
+			l.logger.Info("💾 litestream: closing databases")
+			if err := l.store.Close(l.ctx); err != nil {
+				l.logger.Error("💾 litestream: error closing databases during shutdown", "error", err)
 			} else {
-				l.logger.Debug("💾 litestream: database closed")
+				l.logger.Debug("💾 litestream: databases closed")
 			}
 		}()
+		defer l.stopReplicas()
 
-		l.logger.Info("💾 litestream: starting replication for all configured replicas")
-
-		for _, replica := range l.db.Replicas {
-			rl := l.logger.With("replica_name", replica.Name) // Replica-specific logger
-			rl.Info("💾 litestream: starting replica")
-			// replica.Start runs its own goroutine for syncing
-			if err := replica.Start(l.ctx); err != nil {
-				rl.Error("💾 litestream: CRITICAL - failed to start replica", "error", err)
-				startupErr = fmt.Errorf("failed to start replica '%s': %w", replica.Name, err)
-				break // Stop trying to start other replicas
-			} else {
-				rl.Info("💾 litestream: replica started successfully")
-			}
-		}
+		l.logger.Info("💾 litestream: starting replication for all configured databases")
 
+		startupErr := l.startReplicas()
 		if startupErr != nil {
 			l.logger.Error("💾 litestream: one or more replicas failed to start, initiating shutdown", "error", startupErr)
 			startupComplete <- startupErr // Report the error back to Start() caller
@@ -236,25 +301,107 @@ func (l *Litestream) Start() error {
 
 		l.logger.Info("💾 litestream: all replicas started successfully")
 		startupComplete <- nil // Signal successful startup
+		l.notifier.Notify(NotifyEvent{Type: EventStartupComplete})
+
+		go l.sampleMetrics()
 
 		<-l.ctx.Done()
-		l.logger.Info("💾 litestream: received shutdown signal, initiating replica stop via db.Close()")
-		// db.Close() called by defer will handle stopping replicas
+		l.logger.Info("💾 litestream: received shutdown signal, stopping replicas")
 	}()
 
 	err := <-startupComplete
 	return err
 }
 
+// startReplicas starts every configured replica across every database,
+// bounded by l.replicaGate so at most MaxConcurrentReplicaOps run at once.
+// Unlike a serial loop, a single misconfigured replica does not prevent the
+// others from starting: every error is collected and returned joined via
+// errors.Join.
+func (l *Litestream) startReplicas() error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+
+	for _, e := range l.entries {
+		l.replicaGate.Start()
+		wg.Add(1)
+
+		go func(e replicaEntry) {
+			defer wg.Done()
+			defer l.replicaGate.Done()
+
+			rl := l.logger.With("db", e.dbPath, "replica_name", e.name)
+			rl.Info("💾 litestream: starting replica")
+
+			if err := e.replica.Start(l.ctx); err != nil {
+				rl.Error("💾 litestream: failed to start replica", "error", err)
+				l.notifier.Notify(NotifyEvent{Type: EventReplicaStartFailure, Database: e.dbPath, ReplicaName: e.name, Error: err})
+				l.metrics.setReplicaUp(e.dbPath, e.name, false)
+
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("database '%s': failed to start replica '%s': %w", e.dbPath, e.name, err))
+				mu.Unlock()
+				return
+			}
+			rl.Info("💾 litestream: replica started successfully")
+			l.metrics.setReplicaUp(e.dbPath, e.name, true)
+		}(e)
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+// stopReplicas stops every replica across every database, bounded by
+// l.replicaGate, so a slow or unreachable destination doesn't serialize the
+// teardown of the others. Errors are logged rather than returned since Stop
+// is called during a best-effort shutdown.
+func (l *Litestream) stopReplicas() {
+	var wg sync.WaitGroup
+
+	for _, e := range l.entries {
+		l.replicaGate.Start()
+		wg.Add(1)
+
+		go func(e replicaEntry) {
+			defer wg.Done()
+			defer l.replicaGate.Done()
+
+			rl := l.logger.With("db", e.dbPath, "replica_name", e.name)
+			rl.Info("💾 litestream: stopping replica")
+			// hard=false: let the replica finish syncing in flight data
+			// before releasing its slot, a graceful stop rather than an
+			// abrupt one.
+			if err := e.replica.Stop(false); err != nil {
+				rl.Error("💾 litestream: error stopping replica", "error", err)
+			}
+			l.metrics.setReplicaUp(e.dbPath, e.name, false)
+		}(e)
+	}
+
+	wg.Wait()
+}
+
 // Stop gracefully shuts down the backup process by cancelling the context.
-// It waits until the background goroutine confirms shutdown or the provided context times out.
+// It waits until the background goroutine confirms shutdown or the provided
+// context times out. Stop is idempotent: calling it again after a successful
+// shutdown just returns nil rather than re-notifying and re-closing the
+// already-closed notifier.
 func (l *Litestream) Stop(ctx context.Context) error {
 	l.logger.Info("💾 litestream: stopping backup process")
 	l.cancel() // Signal the background goroutine to stop
 
 	select {
 	case <-l.shutdownDone:
-		l.logger.Info("💾 litestream: stopped gracefully")
+		l.stopOnce.Do(func() {
+			l.logger.Info("💾 litestream: stopped gracefully")
+			l.notifier.Notify(NotifyEvent{Type: EventShutdown})
+			l.notifier.Close()
+		})
 		return nil
 	case <-ctx.Done():
 		l.logger.Info("💾 litestream: shutdown timed out")
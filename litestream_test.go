@@ -0,0 +1,35 @@
+package litestream
+
+import (
+	"context"
+	"testing"
+)
+
+// TestLitestream_StopIsIdempotent guards against a regression where a
+// second Stop call re-entered the already-closed-channel case and called
+// notifier.Close a second time, panicking with "close of closed channel".
+func TestLitestream_StopIsIdempotent(t *testing.T) {
+	_, cancel := context.WithCancel(context.Background())
+
+	shutdownDone := make(chan struct{})
+	close(shutdownDone) // simulate the background goroutine having already exited
+
+	n, err := newNotifier(Config{}, discardLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := &Litestream{
+		logger:       discardLogger(),
+		cancel:       cancel,
+		shutdownDone: shutdownDone,
+		notifier:     n,
+	}
+
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatalf("first Stop() = %v, want nil", err)
+	}
+	if err := l.Stop(context.Background()); err != nil {
+		t.Fatalf("second Stop() = %v, want nil (should be a no-op, not panic)", err)
+	}
+}
@@ -0,0 +1,174 @@
+package litestream
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsSampleInterval controls how often per-replica gauges that depend on
+// wall-clock time (secondsSinceSync) are refreshed.
+const metricsSampleInterval = 10 * time.Second
+
+// metrics holds the prometheus collectors exposed for replication health.
+// All vectors are labeled by "db" and "replica" so two databases that
+// happen to use the same replica name (plausible now that one daemon
+// replicates several databases, see Config.Databases) don't clobber each
+// other's series. bytesUploaded, walBytesPending, snapshotCount, syncErrors
+// and replicaUp are all updated eagerly as the corresponding lifecycle event
+// happens (a write, an error, Start/Stop); only secondsSinceSync depends on
+// wall-clock time and so is refreshed on metricsSampleInterval by sample.
+type metrics struct {
+	reg prometheus.Registerer
+
+	bytesUploaded    *prometheus.CounterVec
+	walBytesPending  *prometheus.GaugeVec
+	snapshotCount    *prometheus.CounterVec
+	secondsSinceSync *prometheus.GaugeVec
+	syncErrors       *prometheus.CounterVec
+	replicaUp        *prometheus.GaugeVec
+
+	mu       sync.Mutex
+	lastSync map[replicaKey]time.Time
+}
+
+// replicaKey identifies a replica across every configured database, since
+// replica names are only required to be unique within a single database.
+type replicaKey struct {
+	db   string
+	name string
+}
+
+// newMetrics creates and registers the litestream metric vectors on reg.
+func newMetrics(reg prometheus.Registerer) *metrics {
+	m := &metrics{
+		reg:      reg,
+		lastSync: make(map[replicaKey]time.Time),
+		bytesUploaded: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "litestream",
+			Name:      "bytes_uploaded_total",
+			Help:      "Total number of bytes uploaded to the replica destination.",
+		}, []string{"db", "replica"}),
+		walBytesPending: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "litestream",
+			Name:      "wal_bytes_pending",
+			Help:      "Size in bytes of the WAL segment currently being uploaded to the replica (0 when idle).",
+		}, []string{"db", "replica"}),
+		snapshotCount: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "litestream",
+			Name:      "snapshots_total",
+			Help:      "Total number of snapshots written to the replica.",
+		}, []string{"db", "replica"}),
+		secondsSinceSync: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "litestream",
+			Name:      "seconds_since_last_sync",
+			Help:      "Seconds elapsed since the replica last synced successfully.",
+		}, []string{"db", "replica"}),
+		syncErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "litestream",
+			Name:      "sync_errors_total",
+			Help:      "Total number of sync errors encountered by the replica.",
+		}, []string{"db", "replica"}),
+		replicaUp: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "litestream",
+			Name:      "replica_up",
+			Help:      "Whether the replica is currently running (1) or not (0).",
+		}, []string{"db", "replica"}),
+	}
+
+	reg.MustRegister(
+		m.bytesUploaded,
+		m.walBytesPending,
+		m.snapshotCount,
+		m.secondsSinceSync,
+		m.syncErrors,
+		m.replicaUp,
+	)
+
+	return m
+}
+
+// recordWrite records a successful upload of n bytes for the replica named
+// name on database db, marks it as having just synced (for
+// secondsSinceSync), and reports it as up: a successful write is the
+// clearest evidence a replica is actually running.
+func (m *metrics) recordWrite(db, name string, n int64) {
+	m.bytesUploaded.WithLabelValues(db, name).Add(float64(n))
+
+	m.mu.Lock()
+	m.lastSync[replicaKey{db, name}] = time.Now()
+	m.mu.Unlock()
+
+	m.setReplicaUp(db, name, true)
+}
+
+// recordSnapshot increments the snapshot counter for the replica named name
+// on database db.
+func (m *metrics) recordSnapshot(db, name string) {
+	m.snapshotCount.WithLabelValues(db, name).Inc()
+}
+
+// recordSyncError increments the sync error counter for the replica named
+// name on database db and reports it as down until its next successful
+// write.
+func (m *metrics) recordSyncError(db, name string) {
+	m.syncErrors.WithLabelValues(db, name).Inc()
+	m.setReplicaUp(db, name, false)
+}
+
+// setReplicaUp reports whether the replica named name on database db is
+// currently running. Callers mark it up once Start succeeds or a write
+// lands, and down on Stop, a sync error or a failed Start.
+func (m *metrics) setReplicaUp(db, name string, up bool) {
+	v := 0.0
+	if up {
+		v = 1.0
+	}
+	m.replicaUp.WithLabelValues(db, name).Set(v)
+}
+
+// sample refreshes secondsSinceSync for the replica named name on database
+// dbPath, a gauge that depends on wall-clock time rather than being
+// updated eagerly from a write.
+func (m *metrics) sample(dbPath, name string) {
+	key := replicaKey{dbPath, name}
+
+	m.mu.Lock()
+	last, ok := m.lastSync[key]
+	m.mu.Unlock()
+	if ok {
+		m.secondsSinceSync.WithLabelValues(dbPath, name).Set(time.Since(last).Seconds())
+	}
+}
+
+// sampleMetrics periodically refreshes the per-replica gauges until the
+// Litestream context is cancelled. It runs alongside the replica goroutines
+// started in Start.
+func (l *Litestream) sampleMetrics() {
+	ticker := time.NewTicker(metricsSampleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.ctx.Done():
+			return
+		case <-ticker.C:
+			for _, e := range l.entries {
+				l.metrics.sample(e.dbPath, e.name)
+			}
+		}
+	}
+}
+
+// Handler returns an http.Handler serving the registered metrics in the
+// Prometheus exposition format, suitable for mounting at "/metrics" on the
+// restinpieces router (e.g. `mux.Handle("/metrics", ls.Handler())`).
+func (l *Litestream) Handler() http.Handler {
+	if g, ok := l.metrics.reg.(prometheus.Gatherer); ok {
+		return promhttp.HandlerFor(g, promhttp.HandlerOpts{})
+	}
+	return promhttp.Handler()
+}
@@ -0,0 +1,219 @@
+package litestream
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/containrrr/shoutrrr"
+	"github.com/containrrr/shoutrrr/pkg/router"
+	"github.com/containrrr/shoutrrr/pkg/types"
+)
+
+// EventType identifies a point in the backup lifecycle that can trigger a
+// notification.
+type EventType string
+
+const (
+	// EventStartupComplete fires once all configured replicas have started.
+	EventStartupComplete EventType = "startup_complete"
+	// EventReplicaStartFailure fires when a replica fails to start.
+	EventReplicaStartFailure EventType = "replica_start_failure"
+	// EventRetentionPrune fires after a replica's retention policy removes
+	// old snapshots/WAL segments.
+	EventRetentionPrune EventType = "retention_prune"
+	// EventSnapshotCompleted fires after a replica finishes a snapshot.
+	EventSnapshotCompleted EventType = "snapshot_completed"
+	// EventShutdown fires when the backup process has fully stopped.
+	EventShutdown EventType = "shutdown"
+	// EventSyncError fires once a replica's consecutive sync error count
+	// reaches Config.SyncErrorThreshold, and again on every error after
+	// that until a successful sync resets the count. It does not fire for
+	// errors below the threshold, so a single transient failure doesn't
+	// page anyone.
+	EventSyncError EventType = "sync_error"
+)
+
+// defaultSyncErrorThreshold is used when Config.SyncErrorThreshold is unset.
+const defaultSyncErrorThreshold = 3
+
+// defaultTemplates holds the built-in message body for each event type,
+// used when Config.NotificationTemplates does not override it.
+var defaultTemplates = map[EventType]string{
+	EventStartupComplete:     "litestream: all replicas started successfully",
+	EventReplicaStartFailure: "litestream: replica {{.ReplicaName}} (db {{.Database}}) failed to start: {{.Error}}",
+	EventRetentionPrune:      "litestream: retention prune completed for replica {{.ReplicaName}} (db {{.Database}})",
+	EventSnapshotCompleted:   "litestream: snapshot completed for replica {{.ReplicaName}} (db {{.Database}}) ({{.BytesReplicated}} bytes, {{.Duration}})",
+	EventShutdown:            "litestream: backup process shut down",
+	EventSyncError:           "litestream: replica {{.ReplicaName}} (db {{.Database}}) sync error: {{.Error}}",
+}
+
+// NotifyEvent carries the data available to a notification template for a
+// given EventType. Not every field is populated for every event: Database
+// and ReplicaName are empty for process-wide events (EventStartupComplete,
+// EventShutdown) that aren't about a single replica.
+type NotifyEvent struct {
+	Type            EventType
+	Database        string
+	ReplicaName     string
+	Error           error
+	BytesReplicated int64
+	Duration        time.Duration
+}
+
+// notifyQueueSize bounds the number of pending notifications. Once full,
+// Notify drops the event rather than blocking the caller.
+const notifyQueueSize = 64
+
+// notifier dispatches lifecycle events to the shoutrrr senders configured in
+// Config.NotificationURLs. It is always non-blocking: events are pushed onto
+// a buffered channel and delivered by a single background worker, so a slow
+// or unreachable notification target never stalls replication.
+type notifier struct {
+	logger    *slog.Logger
+	sender    *router.ServiceRouter
+	templates map[EventType]*template.Template
+	events    chan NotifyEvent
+	done      chan struct{}
+
+	syncErrorThreshold int
+
+	mu          sync.Mutex
+	errorCounts map[replicaKey]int
+}
+
+// newNotifier builds a notifier from the notification URLs and per-event
+// template overrides in cfg. With no URLs configured it still returns a
+// usable notifier that simply logs every event.
+func newNotifier(cfg Config, logger *slog.Logger) (*notifier, error) {
+	syncErrorThreshold := cfg.SyncErrorThreshold
+	if syncErrorThreshold <= 0 {
+		syncErrorThreshold = defaultSyncErrorThreshold
+	}
+
+	n := &notifier{
+		logger:             logger,
+		templates:          make(map[EventType]*template.Template, len(defaultTemplates)),
+		events:             make(chan NotifyEvent, notifyQueueSize),
+		done:               make(chan struct{}),
+		syncErrorThreshold: syncErrorThreshold,
+		errorCounts:        make(map[replicaKey]int),
+	}
+
+	for evt, body := range defaultTemplates {
+		if override, ok := cfg.NotificationTemplates[string(evt)]; ok {
+			body = override
+		}
+		tmpl, err := template.New(string(evt)).Parse(body)
+		if err != nil {
+			return nil, fmt.Errorf("litestream: invalid notification template for event %q: %w", evt, err)
+		}
+		n.templates[evt] = tmpl
+	}
+
+	if len(cfg.NotificationURLs) > 0 {
+		sender, err := shoutrrr.CreateSender(cfg.NotificationURLs...)
+		if err != nil {
+			return nil, fmt.Errorf("litestream: failed to configure notification senders: %w", err)
+		}
+		n.sender = sender
+	}
+
+	go n.run()
+
+	return n, nil
+}
+
+// Notify enqueues evt for delivery. It never blocks: if the queue is full
+// the event is logged and dropped.
+func (n *notifier) Notify(evt NotifyEvent) {
+	select {
+	case n.events <- evt:
+	default:
+		n.logger.Warn("💾 litestream: notification queue full, dropping event", "event", evt.Type)
+	}
+}
+
+// notifySyncError records a sync failure for the replica named name on
+// database db and fires EventSyncError once its consecutive error count
+// reaches n.syncErrorThreshold, and again on every error after that until
+// notifySyncSuccess resets it.
+func (n *notifier) notifySyncError(db, name string, err error) {
+	key := replicaKey{db, name}
+
+	n.mu.Lock()
+	n.errorCounts[key]++
+	count := n.errorCounts[key]
+	n.mu.Unlock()
+
+	if count >= n.syncErrorThreshold {
+		n.Notify(NotifyEvent{Type: EventSyncError, Database: db, ReplicaName: name, Error: err})
+	}
+}
+
+// notifySyncSuccess resets the consecutive error count for the replica
+// named name on database db, so a later failure starts counting from zero
+// again.
+func (n *notifier) notifySyncSuccess(db, name string) {
+	key := replicaKey{db, name}
+
+	n.mu.Lock()
+	delete(n.errorCounts, key)
+	n.mu.Unlock()
+}
+
+// Close stops the dispatch worker. It closes the queue and blocks until the
+// worker has delivered every event already queued; it has no timeout of its
+// own.
+func (n *notifier) Close() {
+	close(n.events)
+	<-n.done
+}
+
+// run is the single dispatch worker. It serializes delivery so notifiers
+// that are not safe for concurrent use (most shoutrrr senders) are never
+// called from multiple goroutines at once.
+func (n *notifier) run() {
+	defer close(n.done)
+
+	for evt := range n.events {
+		n.deliver(evt)
+	}
+}
+
+func (n *notifier) deliver(evt NotifyEvent) {
+	el := n.logger.With("event", evt.Type, "replica_name", evt.ReplicaName)
+
+	body, err := n.render(evt)
+	if err != nil {
+		el.Error("💾 litestream: failed to render notification template", "error", err)
+		return
+	}
+
+	if n.sender == nil {
+		el.Info("💾 litestream: notification (log-only)", "body", body)
+		return
+	}
+
+	for _, result := range n.sender.Send(body, (*types.Params)(nil)) {
+		if result != nil {
+			el.Error("💾 litestream: failed to send notification", "error", result)
+		}
+	}
+}
+
+func (n *notifier) render(evt NotifyEvent) (string, error) {
+	tmpl, ok := n.templates[evt.Type]
+	if !ok {
+		return "", fmt.Errorf("no template registered for event %q", evt.Type)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, evt); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,111 @@
+package litestream
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestNotifier_NotifyDoesNotBlockWhenQueueFull(t *testing.T) {
+	n := &notifier{
+		logger: discardLogger(),
+		events: make(chan NotifyEvent, 2),
+	}
+
+	n.Notify(NotifyEvent{Type: EventShutdown})
+	n.Notify(NotifyEvent{Type: EventShutdown})
+
+	done := make(chan struct{})
+	go func() {
+		n.Notify(NotifyEvent{Type: EventShutdown}) // queue full: must drop, not block
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Notify blocked with a full queue instead of dropping the event")
+	}
+}
+
+func TestNotifier_SyncErrorFiresAtThreshold(t *testing.T) {
+	n := &notifier{
+		logger:             discardLogger(),
+		events:             make(chan NotifyEvent, notifyQueueSize),
+		syncErrorThreshold: 3,
+		errorCounts:        make(map[replicaKey]int),
+	}
+
+	boom := errors.New("boom")
+
+	n.notifySyncError("db", "r1", boom)
+	n.notifySyncError("db", "r1", boom)
+	if got := len(n.events); got != 0 {
+		t.Fatalf("queued events = %d, want 0 before the threshold is reached", got)
+	}
+
+	n.notifySyncError("db", "r1", boom)
+	if got := len(n.events); got != 1 {
+		t.Fatalf("queued events = %d, want 1 once the threshold is reached", got)
+	}
+
+	n.notifySyncError("db", "r1", boom)
+	if got := len(n.events); got != 2 {
+		t.Fatalf("queued events = %d, want 2 for a further error past the threshold", got)
+	}
+
+	n.notifySyncSuccess("db", "r1")
+	n.notifySyncError("db", "r1", boom)
+	if got := len(n.events); got != 2 {
+		t.Fatalf("queued events = %d, want 2: notifySyncSuccess should reset the error count", got)
+	}
+}
+
+func TestNotifier_SyncErrorCountsPerReplica(t *testing.T) {
+	n := &notifier{
+		logger:             discardLogger(),
+		events:             make(chan NotifyEvent, notifyQueueSize),
+		syncErrorThreshold: 2,
+		errorCounts:        make(map[replicaKey]int),
+	}
+
+	boom := errors.New("boom")
+
+	n.notifySyncError("db", "r1", boom)
+	n.notifySyncError("db", "r2", boom)
+	if got := len(n.events); got != 0 {
+		t.Fatalf("queued events = %d, want 0: each replica has its own error count", got)
+	}
+}
+
+func TestNotifier_Render(t *testing.T) {
+	tmpl, err := template.New(string(EventSnapshotCompleted)).Parse(defaultTemplates[EventSnapshotCompleted])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := &notifier{templates: map[EventType]*template.Template{EventSnapshotCompleted: tmpl}}
+
+	got, err := n.render(NotifyEvent{Type: EventSnapshotCompleted, Database: "/data/app.db", ReplicaName: "r1", BytesReplicated: 42, Duration: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "litestream: snapshot completed for replica r1 (db /data/app.db) (42 bytes, 1s)"; got != want {
+		t.Fatalf("render() = %q, want %q", got, want)
+	}
+}
+
+func TestNotifier_RenderUnknownEvent(t *testing.T) {
+	n := &notifier{templates: map[EventType]*template.Template{}}
+
+	if _, err := n.render(NotifyEvent{Type: EventType("bogus")}); err == nil {
+		t.Fatal("render() with no registered template should return an error")
+	}
+}
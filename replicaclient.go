@@ -0,0 +1,108 @@
+package litestream
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/benbjohnson/litestream"
+	"github.com/benbjohnson/litestream/abs"
+	"github.com/benbjohnson/litestream/file"
+	"github.com/benbjohnson/litestream/gs"
+	"github.com/benbjohnson/litestream/s3"
+	"github.com/benbjohnson/litestream/sftp"
+)
+
+// newReplicaClient builds the litestream.ReplicaClient for rc, validating
+// the type-specific fields required for each backend. It is shared by
+// NewLitestream (continuous replication) and Restore (point-in-time
+// recovery) so both paths configure replicas identically. When rc has
+// AgeRecipients configured, the returned client transparently encrypts
+// writes and decrypts reads; ageIdentityPath supplies the identity used for
+// the latter and may be empty if the caller only ever writes.
+func newReplicaClient(rc ReplicaConfig, l *slog.Logger, ageIdentityPath string) (litestream.ReplicaClient, error) {
+	client, err := newRawReplicaClient(rc, l)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rc.AgeRecipients) == 0 {
+		return client, nil
+	}
+
+	return newAgeReplicaClient(client, rc.AgeRecipients, ageIdentityPath)
+}
+
+// newRawReplicaClient builds the unwrapped litestream.ReplicaClient for rc.
+func newRawReplicaClient(rc ReplicaConfig, l *slog.Logger) (litestream.ReplicaClient, error) {
+	switch rc.Type {
+	case "file":
+		if rc.FilePath == "" {
+			return nil, fmt.Errorf("litestream: FilePath is required for file replica '%s'", rc.Name)
+		}
+		if err := os.MkdirAll(rc.FilePath, 0750); err != nil && !os.IsExist(err) {
+			return nil, fmt.Errorf("litestream: failed to create file replica directory '%s' for replica '%s': %w", rc.FilePath, rc.Name, err)
+		}
+		absFilePath, err := filepath.Abs(rc.FilePath)
+		if err != nil {
+			return nil, fmt.Errorf("litestream: failed to get absolute path for file replica '%s' path '%s': %w", rc.Name, rc.FilePath, err)
+		}
+		l.Info("Configured file replica client", "path", absFilePath)
+		return file.NewReplicaClient(absFilePath), nil
+
+	case "s3":
+		s3Client := s3.NewReplicaClient()
+		s3Client.Bucket = rc.S3Bucket
+		s3Client.Path = rc.S3Path
+		s3Client.Region = rc.S3Region
+		s3Client.Endpoint = rc.S3Endpoint
+		s3Client.AccessKeyID = rc.S3AccessKeyID
+		s3Client.SecretAccessKey = rc.S3SecretAccessKey
+		s3Client.ForcePathStyle = rc.S3ForcePathStyle
+		// s3Client.SkipVerify = rc.S3SkipVerify // Add if needed
+
+		l.Info("Configured S3 replica client", "endpoint", rc.S3Endpoint, "bucket", rc.S3Bucket, "path", rc.S3Path, "region", rc.S3Region)
+		return s3Client, nil
+
+	case "gcs":
+		if rc.GCSBucket == "" {
+			return nil, fmt.Errorf("litestream: GCSBucket is required for gcs replica '%s'", rc.Name)
+		}
+		gcsClient := gs.NewReplicaClient()
+		gcsClient.Bucket = rc.GCSBucket
+		gcsClient.Path = rc.GCSPath
+
+		l.Info("Configured GCS replica client", "bucket", rc.GCSBucket, "path", rc.GCSPath)
+		return gcsClient, nil
+
+	case "abs":
+		if rc.ABSAccountName == "" || rc.ABSContainer == "" {
+			return nil, fmt.Errorf("litestream: ABSAccountName and ABSContainer are required for abs replica '%s'", rc.Name)
+		}
+		absClient := abs.NewReplicaClient()
+		absClient.AccountName = rc.ABSAccountName
+		absClient.AccountKey = rc.ABSAccountKey
+		absClient.Bucket = rc.ABSContainer
+		absClient.Path = rc.ABSPath
+
+		l.Info("Configured Azure Blob Storage replica client", "account", rc.ABSAccountName, "container", rc.ABSContainer, "path", rc.ABSPath)
+		return absClient, nil
+
+	case "sftp":
+		if rc.SFTPHost == "" || rc.SFTPUser == "" {
+			return nil, fmt.Errorf("litestream: SFTPHost and SFTPUser are required for sftp replica '%s'", rc.Name)
+		}
+		sftpClient := sftp.NewReplicaClient()
+		sftpClient.Host = rc.SFTPHost
+		sftpClient.User = rc.SFTPUser
+		sftpClient.KeyPath = rc.SFTPKeyPath
+		sftpClient.Path = rc.SFTPPath
+
+		l.Info("Configured SFTP replica client", "host", rc.SFTPHost, "user", rc.SFTPUser, "path", rc.SFTPPath)
+		return sftpClient, nil
+
+	default:
+		return nil, fmt.Errorf("litestream: unsupported replica type '%s' for replica '%s'", rc.Type, rc.Name)
+	}
+}
@@ -0,0 +1,182 @@
+package litestream
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/superfly/ltx"
+
+	"github.com/benbjohnson/litestream"
+)
+
+// RestoreOptions configures a point-in-time recovery performed by Restore.
+type RestoreOptions struct {
+	// DatabasePath narrows the search to the database configured with this
+	// exact Path. When empty, every configured database is considered. This
+	// matters once Config.Databases holds several databases that could
+	// plausibly reuse the same ReplicaName (e.g. "primary" on each): without
+	// it, a replica is picked from whichever database happens to come
+	// first in declaration order.
+	DatabasePath string
+
+	// ReplicaName selects which configured replica to restore from. When
+	// empty, every configured replica (within DatabasePath, if set) is
+	// inspected and the newest one as of Timestamp is used.
+	ReplicaName string
+
+	// TXID pins the restore to a specific transaction ID, litestream's
+	// LTX-format restore point (its CLI exposes this as -txid). The search
+	// must match exactly one replica (use DatabasePath and ReplicaName to
+	// narrow it when several are configured): a bare TXID can't be checked
+	// against every candidate ahead of time, so Restore refuses to guess
+	// which replica it belongs to.
+	TXID ltx.TXID
+
+	// Timestamp restores the database to its state as of this point in
+	// time. Zero means "the latest available state".
+	Timestamp time.Time
+
+	// IfDBNotExists skips the restore without error if a file already
+	// exists at the output path.
+	IfDBNotExists bool
+
+	// IfReplicaExists skips the restore without error instead of returning
+	// an error when no configured replica (or no generation satisfying
+	// Timestamp) can be found.
+	IfReplicaExists bool
+
+	// AgeIdentityPath decrypts replica payloads that were encrypted via
+	// ReplicaConfig.AgeRecipients. Required whenever the source replica has
+	// AgeRecipients configured; pass the same identity file used for
+	// restinpieces config decryption (-age-key).
+	AgeIdentityPath string
+}
+
+// restoreCandidate pairs a configured replica's name with the
+// *litestream.Replica built to read from it, so pickRestoreSource can
+// report which one it picked.
+type restoreCandidate struct {
+	name    string
+	replica *litestream.Replica
+}
+
+// Restore reconstructs a database file at outputPath from one of the
+// replicas configured across cfg.Databases, under ctx. When opts.ReplicaName
+// is empty it considers every configured replica and picks the newest one
+// as of opts.Timestamp. It is the counterpart to NewLitestream/Start: where
+// those keep a live database backed up, Restore recovers one back down from
+// its replicas without needing a running daemon.
+func Restore(ctx context.Context, cfg Config, outputPath string, opts RestoreOptions, logger *slog.Logger) error {
+	if outputPath == "" {
+		return fmt.Errorf("litestream: outputPath cannot be empty")
+	}
+	if len(cfg.Databases) == 0 {
+		return fmt.Errorf("litestream: no databases configured")
+	}
+
+	if opts.IfDBNotExists {
+		if _, err := os.Stat(outputPath); err == nil {
+			logger.Info("💾 litestream: database already exists, skipping restore", "path", outputPath)
+			return nil
+		} else if !os.IsNotExist(err) {
+			return fmt.Errorf("litestream: failed to stat output path '%s': %w", outputPath, err)
+		}
+	}
+
+	db := litestream.NewDB(outputPath)
+	db.Logger = logger.With("db", outputPath)
+
+	var candidates []restoreCandidate
+	for _, dc := range cfg.Databases {
+		if opts.DatabasePath != "" && dc.Path != opts.DatabasePath {
+			continue
+		}
+
+		for _, rc := range dc.Replicas {
+			if opts.ReplicaName != "" && rc.Name != opts.ReplicaName {
+				continue
+			}
+
+			l := logger.With("db", dc.Path, "replica_name", rc.Name, "replica_type", rc.Type)
+			client, err := newReplicaClient(rc, l, opts.AgeIdentityPath)
+			if err != nil {
+				return fmt.Errorf("litestream: failed to configure replica '%s': %w", rc.Name, err)
+			}
+
+			candidates = append(candidates, restoreCandidate{name: rc.Name, replica: litestream.NewReplicaWithClient(db, client)})
+		}
+	}
+
+	if len(candidates) == 0 {
+		if opts.IfReplicaExists {
+			logger.Info("💾 litestream: no matching replica configured, skipping restore", "replica_name", opts.ReplicaName)
+			return nil
+		}
+		return fmt.Errorf("litestream: no replica named '%s' configured", opts.ReplicaName)
+	}
+
+	if opts.TXID != 0 && len(candidates) > 1 {
+		return fmt.Errorf("litestream: -txid matched %d replicas; pass -db and -replica to narrow the restore to exactly one", len(candidates))
+	}
+
+	replica, name, err := pickRestoreSource(ctx, candidates, opts)
+	if err != nil {
+		if opts.IfReplicaExists {
+			logger.Info("💾 litestream: no matching restore point found, skipping restore", "error", err)
+			return nil
+		}
+		return err
+	}
+
+	rl := logger.With("replica_name", name)
+	rl.Info("💾 litestream: restoring database", "path", outputPath, "txid", opts.TXID)
+
+	restoreOpts := litestream.NewRestoreOptions()
+	restoreOpts.OutputPath = outputPath
+	restoreOpts.TXID = opts.TXID
+	restoreOpts.Timestamp = opts.Timestamp
+
+	if err := replica.Restore(ctx, restoreOpts); err != nil {
+		return fmt.Errorf("litestream: restore from replica '%s' failed: %w", name, err)
+	}
+
+	rl.Info("💾 litestream: restore completed successfully")
+	return nil
+}
+
+// pickRestoreSource determines which replica Restore reads from. With
+// opts.TXID set, the caller has already guaranteed candidates holds exactly
+// one entry (see Restore), so it's used as-is. Otherwise every candidate is
+// probed with CalcRestoreTarget, which both validates that it has a restore
+// point satisfying opts.Timestamp and reports that point's time, and the
+// candidate with the most recent one wins.
+func pickRestoreSource(ctx context.Context, candidates []restoreCandidate, opts RestoreOptions) (*litestream.Replica, string, error) {
+	if opts.TXID != 0 {
+		return candidates[0].replica, candidates[0].name, nil
+	}
+
+	var (
+		best     *litestream.Replica
+		bestName string
+		bestTime time.Time
+	)
+
+	for _, c := range candidates {
+		updatedAt, err := c.replica.CalcRestoreTarget(ctx, litestream.RestoreOptions{Timestamp: opts.Timestamp})
+		if err != nil {
+			continue // no restore point on this replica satisfying opts.Timestamp
+		}
+		if best == nil || updatedAt.After(bestTime) {
+			best, bestName, bestTime = c.replica, c.name, updatedAt
+		}
+	}
+
+	if best == nil {
+		return nil, "", fmt.Errorf("litestream: no matching restore point found across configured replicas")
+	}
+
+	return best, bestName, nil
+}